@@ -0,0 +1,534 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+)
+
+// cfgNode is a single basic block in the intra-procedural control-flow
+// graph built for one function body: a maximal run of straight-line code
+// together with the edges to the blocks that can follow it. Statements
+// themselves aren't retained, only the source range they span, since all
+// downstream analyses only need to attribute a line number to a block.
+type cfgNode struct {
+	id    int
+	start token.Pos
+	end   token.Pos
+	succ  map[int]bool
+	pred  map[int]bool
+	// owned holds the statements (and, for headers of branch/loop/switch
+	// constructs, the Init/Cond/Tag expressions) directly attributed to
+	// this block, for callers that need to inspect the code a block runs
+	// -- e.g. to count log calls.
+	owned []ast.Node
+}
+
+func (n *cfgNode) extend(pos token.Pos) {
+	if pos > n.end {
+		n.end = pos
+	}
+}
+
+func (n *cfgNode) attach(node ast.Node) {
+	n.extend(node.End())
+	n.owned = append(n.owned, node)
+}
+
+func link(from, to *cfgNode) {
+	if from == nil || to == nil {
+		return
+	}
+	from.succ[to.id] = true
+	to.pred[from.id] = true
+}
+
+// loopCtx tracks the jump targets for break/continue while building the
+// body of a for/range/switch/select statement, so that labeled and
+// unlabeled branches out of nested constructs resolve to the right block.
+type loopCtx struct {
+	label        string
+	continueDest *cfgNode
+	breakDest    *cfgNode
+}
+
+// cfgBuilder assembles the basic blocks of a single function body by
+// splitting its statement list at every branch, loop, switch/select case,
+// return, goto/label, and defer/go statement.
+type cfgBuilder struct {
+	nodes  []*cfgNode
+	labels map[string]*cfgNode
+	gotos  []gotoEdge
+	loops  []loopCtx
+}
+
+type gotoEdge struct {
+	from  *cfgNode
+	label string
+}
+
+// buildFunctionCFG returns the basic blocks of fn's body in the order they
+// were created. fn.Body must be non-nil.
+func buildFunctionCFG(fn *ast.FuncDecl) []*cfgNode {
+	b := &cfgBuilder{labels: map[string]*cfgNode{}}
+	entry := b.node(fn.Body.Pos())
+	b.stmtList(fn.Body.List, entry)
+	for _, g := range b.gotos {
+		if dst, ok := b.labels[g.label]; ok {
+			link(g.from, dst)
+		}
+	}
+	return prunePhantomJoins(b.nodes, entry)
+}
+
+// prunePhantomJoins drops the join/after block a branch/switch/select
+// construct creates for "once every arm is done" when every arm actually
+// terminates (return, a call that never returns, ...): ifStmt et al.
+// always allocate that block up front, but only link()s something into
+// it for the arms that fall through. If none do, the block has no preds
+// and, since nothing runs after it either, no succs -- it's a phantom
+// with no statements of its own that would otherwise surface as a bogus
+// BasicBlock. entry is always kept, even for an empty function body, so
+// every function still gets at least one block.
+func prunePhantomJoins(nodes []*cfgNode, entry *cfgNode) []*cfgNode {
+	kept := make([]*cfgNode, 0, len(nodes))
+	oldToNew := make(map[int]int, len(nodes))
+	for _, n := range nodes {
+		if n != entry && len(n.pred) == 0 && len(n.succ) == 0 && len(n.owned) == 0 {
+			continue
+		}
+		oldToNew[n.id] = len(kept)
+		kept = append(kept, n)
+	}
+	for _, n := range kept {
+		n.pred = renumberIds(n.pred, oldToNew)
+		n.succ = renumberIds(n.succ, oldToNew)
+	}
+	for i, n := range kept {
+		n.id = i
+	}
+	return kept
+}
+
+func renumberIds(ids map[int]bool, oldToNew map[int]int) map[int]bool {
+	out := make(map[int]bool, len(ids))
+	for id := range ids {
+		out[oldToNew[id]] = true
+	}
+	return out
+}
+
+func (b *cfgBuilder) node(pos token.Pos) *cfgNode {
+	n := &cfgNode{id: len(b.nodes), start: pos, end: pos, succ: map[int]bool{}, pred: map[int]bool{}}
+	b.nodes = append(b.nodes, n)
+	return n
+}
+
+// ensure returns cur, or if control flow could not reach this point (e.g.
+// code following a return/goto/break), opens a fresh unreferenced block so
+// the dead statements still get attributed to a block.
+func (b *cfgBuilder) ensure(cur *cfgNode, pos token.Pos) *cfgNode {
+	if cur == nil {
+		return b.node(pos)
+	}
+	return cur
+}
+
+func (b *cfgBuilder) stmtList(stmts []ast.Stmt, cur *cfgNode) *cfgNode {
+	for _, stmt := range stmts {
+		cur = b.stmt(stmt, cur)
+	}
+	return cur
+}
+
+func (b *cfgBuilder) stmt(stmt ast.Stmt, cur *cfgNode) *cfgNode {
+	switch s := stmt.(type) {
+	case *ast.LabeledStmt:
+		cur = b.ensure(cur, s.Pos())
+		head := b.node(s.Pos())
+		link(cur, head)
+		b.labels[s.Label.Name] = head
+		switch inner := s.Stmt.(type) {
+		case *ast.ForStmt:
+			return b.forStmt(inner, head, s.Label.Name)
+		case *ast.RangeStmt:
+			return b.rangeStmt(inner, head, s.Label.Name)
+		case *ast.SwitchStmt:
+			return b.switchStmt(inner, head, s.Label.Name)
+		case *ast.TypeSwitchStmt:
+			return b.typeSwitchStmt(inner, head, s.Label.Name)
+		case *ast.SelectStmt:
+			return b.selectStmt(inner, head, s.Label.Name)
+		default:
+			return b.stmt(s.Stmt, head)
+		}
+
+	case *ast.BlockStmt:
+		return b.stmtList(s.List, cur)
+
+	case *ast.IfStmt:
+		cur = b.ensure(cur, s.Pos())
+		if s.Init != nil {
+			cur.attach(s.Init)
+		}
+		cur.attach(s.Cond)
+		thenEntry := b.node(s.Body.Pos())
+		link(cur, thenEntry)
+		thenExit := b.stmtList(s.Body.List, thenEntry)
+		join := b.node(s.End())
+		link(thenExit, join)
+		if s.Else != nil {
+			elseEntry := b.node(s.Else.Pos())
+			link(cur, elseEntry)
+			var elseExit *cfgNode
+			if blk, ok := s.Else.(*ast.BlockStmt); ok {
+				elseExit = b.stmtList(blk.List, elseEntry)
+			} else {
+				// else-if chain
+				elseExit = b.stmt(s.Else, elseEntry)
+			}
+			link(elseExit, join)
+		} else {
+			link(cur, join)
+		}
+		return join
+
+	case *ast.ForStmt:
+		return b.forStmt(s, cur, "")
+
+	case *ast.RangeStmt:
+		return b.rangeStmt(s, cur, "")
+
+	case *ast.SwitchStmt:
+		return b.switchStmt(s, cur, "")
+
+	case *ast.TypeSwitchStmt:
+		return b.typeSwitchStmt(s, cur, "")
+
+	case *ast.SelectStmt:
+		return b.selectStmt(s, cur, "")
+
+	case *ast.ReturnStmt:
+		cur = b.ensure(cur, s.Pos())
+		cur.attach(s)
+		return nil
+
+	case *ast.BranchStmt:
+		cur = b.ensure(cur, s.Pos())
+		cur.attach(s)
+		if s.Tok == token.FALLTHROUGH {
+			return cur
+		}
+		b.branch(s, cur)
+		return nil
+
+	case *ast.GoStmt:
+		cur = b.ensure(cur, s.Pos())
+		cur.attach(s)
+		next := b.node(s.End())
+		link(cur, next)
+		return next
+
+	case *ast.DeferStmt:
+		cur = b.ensure(cur, s.Pos())
+		cur.attach(s)
+		next := b.node(s.End())
+		link(cur, next)
+		return next
+
+	default:
+		cur = b.ensure(cur, stmt.Pos())
+		cur.attach(stmt)
+		return cur
+	}
+}
+
+func (b *cfgBuilder) forStmt(s *ast.ForStmt, cur *cfgNode, label string) *cfgNode {
+	cur = b.ensure(cur, s.Pos())
+	if s.Init != nil {
+		cur.attach(s.Init)
+	}
+	header := b.node(s.Pos())
+	link(cur, header)
+	if s.Cond != nil {
+		header.attach(s.Cond)
+	}
+
+	var post *cfgNode
+	continueDest := header
+	if s.Post != nil {
+		post = b.node(s.Post.Pos())
+		post.attach(s.Post)
+		continueDest = post
+	}
+
+	after := b.node(s.End())
+	b.loops = append(b.loops, loopCtx{label: label, continueDest: continueDest, breakDest: after})
+
+	bodyEntry := b.node(s.Body.Pos())
+	link(header, bodyEntry)
+	bodyExit := b.stmtList(s.Body.List, bodyEntry)
+
+	b.loops = b.loops[:len(b.loops)-1]
+
+	if post != nil {
+		link(bodyExit, post)
+		link(post, header)
+	} else {
+		link(bodyExit, header)
+	}
+
+	if s.Cond != nil {
+		link(header, after)
+	}
+	return after
+}
+
+func (b *cfgBuilder) rangeStmt(s *ast.RangeStmt, cur *cfgNode, label string) *cfgNode {
+	cur = b.ensure(cur, s.Pos())
+	header := b.node(s.Pos())
+	link(cur, header)
+
+	after := b.node(s.End())
+	b.loops = append(b.loops, loopCtx{label: label, continueDest: header, breakDest: after})
+
+	bodyEntry := b.node(s.Body.Pos())
+	link(header, bodyEntry)
+	bodyExit := b.stmtList(s.Body.List, bodyEntry)
+	link(bodyExit, header)
+
+	b.loops = b.loops[:len(b.loops)-1]
+
+	link(header, after)
+	return after
+}
+
+func (b *cfgBuilder) switchStmt(s *ast.SwitchStmt, cur *cfgNode, label string) *cfgNode {
+	cur = b.ensure(cur, s.Pos())
+	if s.Init != nil {
+		cur.attach(s.Init)
+	}
+	if s.Tag != nil {
+		cur.attach(s.Tag)
+	}
+	join := b.node(s.End())
+	b.loops = append(b.loops, loopCtx{label: label, breakDest: join})
+
+	hasDefault := false
+	var fallthroughExit *cfgNode
+	for _, clause := range s.Body.List {
+		cc := clause.(*ast.CaseClause)
+		if cc.List == nil {
+			hasDefault = true
+		}
+		caseEntry := b.node(cc.Pos())
+		link(cur, caseEntry)
+		if fallthroughExit != nil {
+			link(fallthroughExit, caseEntry)
+			fallthroughExit = nil
+		}
+		exit := b.stmtList(cc.Body, caseEntry)
+		if exit != nil && endsWithFallthrough(cc.Body) {
+			fallthroughExit = exit
+		} else {
+			link(exit, join)
+		}
+	}
+	b.loops = b.loops[:len(b.loops)-1]
+	if !hasDefault {
+		link(cur, join)
+	}
+	return join
+}
+
+func (b *cfgBuilder) typeSwitchStmt(s *ast.TypeSwitchStmt, cur *cfgNode, label string) *cfgNode {
+	cur = b.ensure(cur, s.Pos())
+	if s.Init != nil {
+		cur.attach(s.Init)
+	}
+	join := b.node(s.End())
+	b.loops = append(b.loops, loopCtx{label: label, breakDest: join})
+
+	hasDefault := false
+	for _, clause := range s.Body.List {
+		cc := clause.(*ast.CaseClause)
+		if cc.List == nil {
+			hasDefault = true
+		}
+		caseEntry := b.node(cc.Pos())
+		link(cur, caseEntry)
+		exit := b.stmtList(cc.Body, caseEntry)
+		link(exit, join)
+	}
+	b.loops = b.loops[:len(b.loops)-1]
+	if !hasDefault {
+		link(cur, join)
+	}
+	return join
+}
+
+func (b *cfgBuilder) selectStmt(s *ast.SelectStmt, cur *cfgNode, label string) *cfgNode {
+	cur = b.ensure(cur, s.Pos())
+	join := b.node(s.End())
+	b.loops = append(b.loops, loopCtx{label: label, breakDest: join})
+
+	for _, clause := range s.Body.List {
+		cc := clause.(*ast.CommClause)
+		caseEntry := b.node(cc.Pos())
+		link(cur, caseEntry)
+		exit := b.stmtList(cc.Body, caseEntry)
+		link(exit, join)
+	}
+	b.loops = b.loops[:len(b.loops)-1]
+	return join
+}
+
+func endsWithFallthrough(stmts []ast.Stmt) bool {
+	if len(stmts) == 0 {
+		return false
+	}
+	branch, ok := stmts[len(stmts)-1].(*ast.BranchStmt)
+	return ok && branch.Tok == token.FALLTHROUGH
+}
+
+func (b *cfgBuilder) branch(s *ast.BranchStmt, cur *cfgNode) {
+	switch s.Tok {
+	case token.GOTO:
+		b.gotos = append(b.gotos, gotoEdge{from: cur, label: s.Label.Name})
+	case token.BREAK:
+		if dest := b.loopDest(s.Label, true); dest != nil {
+			link(cur, dest)
+		}
+	case token.CONTINUE:
+		if dest := b.loopDest(s.Label, false); dest != nil {
+			link(cur, dest)
+		}
+	}
+}
+
+func (b *cfgBuilder) loopDest(label *ast.Ident, isBreak bool) *cfgNode {
+	name := ""
+	if label != nil {
+		name = label.Name
+	}
+	for i := len(b.loops) - 1; i >= 0; i-- {
+		l := b.loops[i]
+		if name != "" && l.label != name {
+			continue
+		}
+		if isBreak {
+			return l.breakDest
+		}
+		if l.continueDest != nil {
+			return l.continueDest
+		}
+		if name != "" {
+			return nil
+		}
+	}
+	return nil
+}
+
+// cfgComplexity derives the McCabe cyclomatic complexity of a function
+// directly from its control-flow graph: edges - nodes + 2. That formula
+// assumes a single connected component, which doesn't hold as-is here --
+// every early return (and the fall-off-the-end block) is a terminal node
+// with no successor, so a function with more than one such exit (e.g. the
+// common guard-clause style) ends up as several disconnected sink
+// components. To compensate, every terminal block is linked to a single
+// virtual exit node before counting, the same trick used when computing
+// complexity from a CFG with multiple exits.
+func cfgComplexity(nodes []*cfgNode) int {
+	edges, sinks := 0, 0
+	for _, n := range nodes {
+		edges += len(n.succ)
+		if len(n.succ) == 0 {
+			sinks++
+		}
+	}
+	// virtual exit node plus one virtual edge per terminal block.
+	totalNodes := len(nodes) + 1
+	totalEdges := edges + sinks
+	return totalEdges - totalNodes + 2
+}
+
+// buildBasicBlocks decomposes every function declared in f into its basic
+// blocks and returns one BasicBlock record per block. All blocks
+// belonging to the same function share its PkgName/FuncName/Complexity/
+// Pos/EndPos; ID, beginLineNo/endLineNo and predIds/succIds identify the
+// block itself and its place in the function's CFG.
+//
+// IDs are assigned locally, starting at 1 within this file, since f is
+// analyzed independently of every other file a caller may be processing
+// concurrently; mergeResults renumbers them to be globally unique.
+func buildBasicBlocks(f *ast.File, fset *token.FileSet) []BasicBlock {
+	var BasicBlocks []BasicBlock
+	imports := fileImports(f)
+	nextID := 1
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		pkgName := f.Name.Name
+		name := funcName(fn)
+		pos := fset.Position(fn.Pos())
+		endPos := fset.Position(fn.End())
+
+		if fn.Body == nil {
+			id := nextID
+			nextID++
+			BasicBlocks = append(BasicBlocks, BasicBlock{
+				PkgName:     pkgName,
+				FuncName:    name,
+				Complexity:  1,
+				Pos:         pos,
+				EndPos:      endPos,
+				ID:          id,
+				beginLineNo: int32(pos.Line),
+				endLineNo:   int32(endPos.Line),
+			})
+			continue
+		}
+
+		nodes := buildFunctionCFG(fn)
+		complexity := cfgComplexity(nodes)
+
+		ids := make([]int, len(nodes))
+		for i := range nodes {
+			ids[i] = nextID
+			nextID++
+		}
+		for _, n := range nodes {
+			logCounts := countLogCalls(n.owned, imports)
+			BasicBlocks = append(BasicBlocks, BasicBlock{
+				PkgName:     pkgName,
+				FuncName:    name,
+				Complexity:  complexity,
+				Pos:         pos,
+				EndPos:      endPos,
+				ID:          ids[n.id],
+				NumTrace:    logCounts[LevelTrace],
+				NumDebug:    logCounts[LevelDebug],
+				NumInfo:     logCounts[LevelInfo],
+				NumWarn:     logCounts[LevelWarn],
+				NumError:    logCounts[LevelError],
+				NumFatal:    logCounts[LevelFatal],
+				beginLineNo: int32(fset.Position(n.start).Line),
+				endLineNo:   int32(fset.Position(n.end).Line),
+				predIds:     globalIDs(n.pred, ids),
+				succIds:     globalIDs(n.succ, ids),
+			})
+		}
+	}
+	return BasicBlocks
+}
+
+func globalIDs(local map[int]bool, ids []int) []int32 {
+	out := make([]int32, 0, len(local))
+	for id := range local {
+		out = append(out, int32(ids[id]))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+// parseFunc parses src (a single top-level function declaration) and
+// returns its *ast.FuncDecl.
+func parseFunc(t *testing.T, src string) *ast.FuncDecl {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", "package p\n"+src, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			return fn
+		}
+	}
+	t.Fatalf("no function declaration found in source")
+	return nil
+}
+
+func TestCfgComplexity(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want int
+	}{
+		{
+			name: "straight line",
+			src:  `func f() { x := 1; _ = x }`,
+			want: 1,
+		},
+		{
+			name: "single if",
+			src:  `func f(a bool) { if a { return } }`,
+			want: 2,
+		},
+		{
+			name: "if/else",
+			src:  `func f(a bool) int { if a { return 1 }; return 2 }`,
+			want: 2,
+		},
+		{
+			name: "three guard clauses",
+			src: `func f(a, b, c bool) int {
+				if a { return 1 }
+				if b { return 2 }
+				if c { return 3 }
+				return 4
+			}`,
+			want: 4,
+		},
+		{
+			name: "for loop",
+			src:  `func f(n int) { for i := 0; i < n; i++ { _ = i } }`,
+			want: 2,
+		},
+		{
+			name: "switch three cases",
+			src: `func f(x int) int {
+				switch x {
+				case 1:
+					return 1
+				case 2:
+					return 2
+				default:
+					return 3
+				}
+			}`,
+			want: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := parseFunc(t, tt.src)
+			nodes := buildFunctionCFG(fn)
+			got := cfgComplexity(nodes)
+			if got != tt.want {
+				t.Errorf("cfgComplexity() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFunctionCFGPrunesPhantomJoins(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		wantNodes int
+	}{
+		{
+			name: "if/else both return",
+			src: `func f(a bool) int {
+				if a {
+					return 1
+				} else {
+					return 2
+				}
+			}`,
+			wantNodes: 3, // entry, then-branch, else-branch -- no join
+		},
+		{
+			name: "switch with default, every case returns",
+			src: `func f(x int) int {
+				switch x {
+				case 1:
+					return 1
+				case 2:
+					return 2
+				default:
+					return 3
+				}
+			}`,
+			wantNodes: 4, // entry + 3 cases -- no join
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := parseFunc(t, tt.src)
+			nodes := buildFunctionCFG(fn)
+			if len(nodes) != tt.wantNodes {
+				t.Errorf("buildFunctionCFG() returned %d nodes, want %d", len(nodes), tt.wantNodes)
+			}
+			for _, n := range nodes {
+				if len(n.pred) == 0 && len(n.succ) == 0 && len(n.owned) == 0 {
+					t.Errorf("node %d is a phantom: no preds, no succs, no owned statements", n.id)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFunctionCFGPredSucc(t *testing.T) {
+	fn := parseFunc(t, `func f(a bool) int {
+		if a {
+			return 1
+		}
+		return 2
+	}`)
+	nodes := buildFunctionCFG(fn)
+
+	byID := map[int]*cfgNode{}
+	for _, n := range nodes {
+		byID[n.id] = n
+	}
+	for _, n := range nodes {
+		for succID := range n.succ {
+			if !byID[succID].pred[n.id] {
+				t.Errorf("node %d has successor %d, but %d is missing %d as a predecessor", n.id, succID, succID, n.id)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var parallelism = flag.Int("j", runtime.NumCPU(), "number of files to analyze in parallel")
+
+// analyze walks paths to collect the .go files to analyze, then fans them
+// out to workers workers, each owning a private token.FileSet so no state
+// is shared while parsing and building basic blocks. Results are merged
+// back in input order, with IDs (and the predIds/succIds that reference
+// them) renumbered to stay globally unique -- the only part that must
+// happen serially.
+func analyze(paths []string, workers int) []BasicBlock {
+	files := collectFiles(paths)
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([][]BasicBlock, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = analyzeFile(files[i])
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return mergeResults(results)
+}
+
+// collectFiles expands any directories in paths into the .go files they
+// contain, while explicit file arguments are kept as-is regardless of
+// extension.
+func collectFiles(paths []string) []string {
+	var files []string
+	for _, path := range paths {
+		if isDir(path) {
+			filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() && strings.HasSuffix(p, ".go") {
+					files = append(files, p)
+				}
+				return err
+			})
+		} else {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+func analyzeFile(fname string) []BasicBlock {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, fname, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return buildBasicBlocks(f, fset)
+}
+
+// mergeResults concatenates each file's blocks in input order, offsetting
+// every ID (and the predIds/succIds that reference one) so they stay
+// unique across the whole run.
+func mergeResults(results [][]BasicBlock) []BasicBlock {
+	var out []BasicBlock
+	nextID := 1
+	for _, blocks := range results {
+		offset := int32(nextID - 1)
+		for _, b := range blocks {
+			b.ID += int(offset)
+			b.predIds = offsetIDs(b.predIds, offset)
+			b.succIds = offsetIDs(b.succIds, offset)
+			out = append(out, b)
+		}
+		nextID += len(blocks)
+	}
+	return out
+}
+
+func offsetIDs(ids []int32, offset int32) []int32 {
+	if len(ids) == 0 {
+		return ids
+	}
+	out := make([]int32, len(ids))
+	for i, id := range ids {
+		out[i] = id + offset
+	}
+	return out
+}
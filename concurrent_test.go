@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// corpus returns a reasonably large, real-world set of files to analyze:
+// the net/http package from the standard library that ships with the Go
+// toolchain running the benchmark.
+func corpus(b *testing.B) string {
+	b.Helper()
+	dir := filepath.Join(runtime.GOROOT(), "src", "net", "http")
+	if !isDir(dir) {
+		b.Skipf("standard library source not found at %s", dir)
+	}
+	return dir
+}
+
+func BenchmarkAnalyzeSerial(b *testing.B) {
+	dir := corpus(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analyze([]string{dir}, 1)
+	}
+}
+
+func BenchmarkAnalyzeParallel(b *testing.B) {
+	dir := corpus(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analyze([]string{dir}, runtime.NumCPU())
+	}
+}
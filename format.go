@@ -0,0 +1,291 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"sort"
+)
+
+var (
+	formatFlag = flag.String("format", "text", "output format: text, json, or sarif")
+	overFlag   = flag.Int("over", 0, "show only functions with complexity > N (also drives the exit code)")
+	topFlag    = flag.Int("top", 0, "limit output to the N most complex functions (0 = no limit)")
+	avgFlag    = flag.Bool("avg", false, "print the average complexity across all functions (text format only)")
+)
+
+// funcStat is one row of the gocyclo-compatible function-level view:
+// BasicBlock decomposes a function into many rows (one per CFG block), but
+// -over/-top/-avg and the classic text/SARIF output operate per function.
+type funcStat struct {
+	PkgName    string
+	FuncName   string
+	Complexity int
+	Pos        token.Position
+	EndPos     token.Position
+}
+
+// funcID identifies the function a BasicBlock belongs to: stable and
+// unique within one analysis run, same scheme placement uses to group
+// blocks back into functions.
+func funcID(b BasicBlock) string {
+	return fmt.Sprintf("%s:%d", b.Pos.Filename, b.Pos.Offset)
+}
+
+// funcStats reduces blocks to one entry per function, sorted by
+// complexity descending (ties broken by position) like gocyclo.
+func funcStats(blocks []BasicBlock) []funcStat {
+	seen := map[string]bool{}
+	var out []funcStat
+	for _, b := range blocks {
+		id := funcID(b)
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, funcStat{
+			PkgName:    b.PkgName,
+			FuncName:   b.FuncName,
+			Complexity: b.Complexity,
+			Pos:        b.Pos,
+			EndPos:     b.EndPos,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Complexity != out[j].Complexity {
+			return out[i].Complexity > out[j].Complexity
+		}
+		return out[i].Pos.String() < out[j].Pos.String()
+	})
+	return out
+}
+
+func average(stats []funcStat) float64 {
+	if len(stats) == 0 {
+		return 0
+	}
+	var sum int
+	for _, s := range stats {
+		sum += s.Complexity
+	}
+	return float64(sum) / float64(len(stats))
+}
+
+// writeOutput renders blocks in the requested format and reports the
+// process exit code: 1 if -over filtered out at least one function (so
+// CI can fail the build), 0 otherwise. An unrecognized -format value is
+// a usage error, so it's reported the same way flag.Parse failures are:
+// log.Fatal, rather than silently falling back to text.
+func writeOutput(w io.Writer, blocks []BasicBlock) int {
+	stats := funcStats(blocks)
+	avg := average(stats)
+
+	over := stats
+	if *overFlag > 0 {
+		over = filterOver(stats, *overFlag)
+	}
+	top := over
+	if *topFlag > 0 && *topFlag < len(top) {
+		top = top[:*topFlag]
+	}
+
+	kept := blocksInFuncs(blocks, top)
+
+	switch *formatFlag {
+	case "json":
+		writeJSON(w, kept, len(top), avg)
+	case "sarif":
+		writeSARIF(w, top)
+	case "text":
+		writeText(w, kept, avg)
+	default:
+		log.Fatalf("unrecognized -format %q: want text, json, or sarif", *formatFlag)
+	}
+
+	if *overFlag > 0 && len(over) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// blocksInFuncs returns the blocks belonging to one of stats' functions,
+// grouped and ordered by stats (funcStats' complexity-descending order)
+// so -top/-over keep ranking their blocks the same way writeSARIF ranks
+// its results; it's the identity filter when -over/-top weren't used to
+// narrow stats down.
+func blocksInFuncs(blocks []BasicBlock, stats []funcStat) []BasicBlock {
+	if *overFlag == 0 && *topFlag == 0 {
+		return blocks
+	}
+	byFunc := make(map[string][]BasicBlock, len(stats))
+	for _, b := range blocks {
+		pos := b.Pos.String()
+		byFunc[pos] = append(byFunc[pos], b)
+	}
+	var out []BasicBlock
+	for _, s := range stats {
+		out = append(out, byFunc[s.Pos.String()]...)
+	}
+	return out
+}
+
+func filterOver(stats []funcStat, over int) []funcStat {
+	var out []funcStat
+	for _, s := range stats {
+		if s.Complexity > over {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// writeText prints one line per basic block -- the detailed CFG view
+// writeBasicBlocks produced before -format existed -- restricted to
+// whichever functions survived -over/-top.
+func writeText(w io.Writer, blocks []BasicBlock, avg float64) {
+	writeBasicBlocks(w, blocks)
+	if *avgFlag {
+		fmt.Fprintf(w, "Average: %.2f\n", avg)
+	}
+}
+
+type jsonOutput struct {
+	Blocks    []BasicBlock `json:"blocks"`
+	Functions int          `json:"functions"`
+	Average   float64      `json:"average"`
+}
+
+func writeJSON(w io.Writer, blocks []BasicBlock, numFuncs int, avg float64) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonOutput{Blocks: blocks, Functions: numFuncs, Average: avg})
+}
+
+// sarifLog is the minimal SARIF 2.1.0 document shape log20 emits.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine"`
+}
+
+// writeSARIF emits one SARIF result per over-threshold function, so CI
+// systems like GitHub code scanning and GitLab can surface them inline on
+// a pull request's diff.
+func writeSARIF(w io.Writer, stats []funcStat) {
+	results := make([]sarifResult, 0, len(stats))
+	for _, s := range stats {
+		results = append(results, sarifResult{
+			RuleID: "complexity",
+			Level:  "warning",
+			Message: sarifMessage{
+				Text: fmt.Sprintf("%s has cyclomatic complexity %d", s.FuncName, s.Complexity),
+			},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: s.Pos.Filename},
+					Region:           sarifRegion{StartLine: s.Pos.Line, EndLine: s.EndPos.Line},
+				},
+			}},
+		})
+	}
+
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "log20", Version: "0.1.0"}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(log)
+}
+
+// MarshalJSON exposes BasicBlock's unexported fields (beginLineNo,
+// endLineNo, predIds, succIds) to -format=json consumers without making
+// them part of the package's exported API.
+func (s BasicBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		PkgName    string         `json:"pkgName"`
+		FuncName   string         `json:"funcName"`
+		Complexity int            `json:"complexity"`
+		Pos        token.Position `json:"pos"`
+		EndPos     token.Position `json:"endPos"`
+		ID         int            `json:"id"`
+		NumTrace   int32          `json:"numTrace"`
+		NumDebug   int32          `json:"numDebug"`
+		NumInfo    int32          `json:"numInfo"`
+		NumWarn    int32          `json:"numWarn"`
+		NumError   int32          `json:"numError"`
+		NumFatal   int32          `json:"numFatal"`
+		BeginLine  int32          `json:"beginLine"`
+		EndLine    int32          `json:"endLine"`
+		PredIds    []int32        `json:"predIds"`
+		SuccIds    []int32        `json:"succIds"`
+	}{
+		PkgName:    s.PkgName,
+		FuncName:   s.FuncName,
+		Complexity: s.Complexity,
+		Pos:        s.Pos,
+		EndPos:     s.EndPos,
+		ID:         s.ID,
+		NumTrace:   s.NumTrace,
+		NumDebug:   s.NumDebug,
+		NumInfo:    s.NumInfo,
+		NumWarn:    s.NumWarn,
+		NumError:   s.NumError,
+		NumFatal:   s.NumFatal,
+		BeginLine:  s.beginLineNo,
+		EndLine:    s.endLineNo,
+		PredIds:    s.predIds,
+		SuccIds:    s.succIds,
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func block(pkg, fn string, complexity int, file string, line int) BasicBlock {
+	pos := token.Position{Filename: file, Line: line}
+	return BasicBlock{PkgName: pkg, FuncName: fn, Complexity: complexity, Pos: pos, EndPos: pos}
+}
+
+func TestFuncStatsDedupesAndSortsByComplexityDescending(t *testing.T) {
+	blocks := []BasicBlock{
+		block("p", "low", 2, "a.go", 1),
+		block("p", "low", 2, "a.go", 1), // second block of the same function
+		block("p", "high", 9, "b.go", 1),
+		block("p", "mid", 5, "c.go", 1),
+	}
+	stats := funcStats(blocks)
+	if len(stats) != 3 {
+		t.Fatalf("funcStats() returned %d entries, want 3", len(stats))
+	}
+	want := []string{"high", "mid", "low"}
+	for i, name := range want {
+		if stats[i].FuncName != name {
+			t.Errorf("stats[%d].FuncName = %q, want %q", i, stats[i].FuncName, name)
+		}
+	}
+}
+
+func TestAverage(t *testing.T) {
+	stats := []funcStat{{Complexity: 2}, {Complexity: 4}, {Complexity: 6}}
+	if got, want := average(stats), 4.0; got != want {
+		t.Errorf("average() = %v, want %v", got, want)
+	}
+	if got := average(nil); got != 0 {
+		t.Errorf("average(nil) = %v, want 0", got)
+	}
+}
+
+func TestFilterOver(t *testing.T) {
+	stats := []funcStat{{FuncName: "a", Complexity: 1}, {FuncName: "b", Complexity: 5}, {FuncName: "c", Complexity: 10}}
+	got := filterOver(stats, 4)
+	if len(got) != 2 {
+		t.Fatalf("filterOver() returned %d entries, want 2", len(got))
+	}
+	if got[0].FuncName != "b" || got[1].FuncName != "c" {
+		t.Errorf("filterOver() = %+v, want b then c", got)
+	}
+}
+
+func TestWriteJSONRoundTrips(t *testing.T) {
+	blocks := []BasicBlock{block("p", "f", 3, "a.go", 1)}
+	var buf bytes.Buffer
+	writeJSON(&buf, blocks, 1, 3.0)
+
+	var out jsonOutput
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.Functions != 1 || out.Average != 3.0 || len(out.Blocks) != 1 {
+		t.Errorf("writeJSON() round-trip = %+v", out)
+	}
+	if out.Blocks[0].FuncName != "f" {
+		t.Errorf("Blocks[0].FuncName = %q, want %q", out.Blocks[0].FuncName, "f")
+	}
+}
+
+func TestWriteSARIFIncludesComplexityMessage(t *testing.T) {
+	stats := []funcStat{{FuncName: "f", Complexity: 12, Pos: token.Position{Filename: "a.go", Line: 1}, EndPos: token.Position{Line: 5}}}
+	var buf bytes.Buffer
+	writeSARIF(&buf, stats)
+
+	var out sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(out.Runs) != 1 || len(out.Runs[0].Results) != 1 {
+		t.Fatalf("writeSARIF() produced %+v", out)
+	}
+	msg := out.Runs[0].Results[0].Message.Text
+	if !strings.Contains(msg, "f") || !strings.Contains(msg, "12") {
+		t.Errorf("SARIF message = %q, want it to mention func name and complexity", msg)
+	}
+}
@@ -4,18 +4,14 @@ import (
 	"flag"
 	"fmt"
 	"go/ast"
-	"go/parser"
 	"go/token"
 	"io"
 	"log"
 	"math"
 	"os"
-	"path/filepath"
-	"strings"
-	"sync"
 )
 
-var count = 1
+var logSinksConfig = flag.String("logsinks", "", "path to a JSON file of extra LogSink entries ([{\"pkg\":..,\"recv\":..,\"method\":..,\"level\":..}])")
 
 func main() {
 	log.SetFlags(0)
@@ -25,22 +21,21 @@ func main() {
 	if len(args) == 0 {
 		os.Exit(2)
 	}
+	if *logSinksConfig != "" {
+		if err := loadLogSinksConfig(*logSinksConfig); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	var counterMutex = &sync.Mutex{}
-	BasicBlocks := analyze(args, counterMutex)
-	writeBasicBlocks(os.Stdout, BasicBlocks)
-}
+	BasicBlocks := analyze(args, *parallelism)
 
-func analyze(paths []string, counterMutex *sync.Mutex) []BasicBlock {
-	var BasicBlocks []BasicBlock
-	for _, path := range paths {
-		if isDir(path) {
-			BasicBlocks = analyzeDir(path, BasicBlocks, counterMutex)
-		} else {
-			BasicBlocks = analyzeFile(path, BasicBlocks, counterMutex)
+	if *recommendFlag {
+		if err := recommendPlacements(os.Stdout, BasicBlocks); err != nil {
+			log.Fatal(err)
 		}
+		return
 	}
-	return BasicBlocks
+	os.Exit(writeOutput(os.Stdout, BasicBlocks))
 }
 
 func isDir(filename string) bool {
@@ -48,25 +43,6 @@ func isDir(filename string) bool {
 	return err == nil && fi.IsDir()
 }
 
-func analyzeFile(fname string, BasicBlocks []BasicBlock, counterMutex *sync.Mutex) []BasicBlock {
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, fname, nil, 0)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return buildBasicBlocks(f, fset, BasicBlocks, counterMutex)
-}
-
-func analyzeDir(dirname string, BasicBlocks []BasicBlock, counterMutex *sync.Mutex) []BasicBlock {
-	filepath.Walk(dirname, func(path string, info os.FileInfo, err error) error {
-		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".go") {
-			BasicBlocks = analyzeFile(path, BasicBlocks, counterMutex)
-		}
-		return err
-	})
-	return BasicBlocks
-}
-
 func writeBasicBlocks(w io.Writer, sortedBasicBlocks []BasicBlock) int {
 	for _, BasicBlock := range sortedBasicBlocks {
 		fmt.Fprintln(w, BasicBlock)
@@ -84,40 +60,22 @@ type BasicBlock struct {
 	ID         int
 	// MethodSignature string
 	// BasicBlockID    int32
-	NumTrace        int32
-	NumDebug        int32
-	NumInfo         int32
-	NumWarn         int32
-	NumError        int32
-	NumFatal        int32
-	beginLineNo     int32
-	endLineNo       int32
-	predIds         []int32
-	succIds         []int32
+	NumTrace    int32
+	NumDebug    int32
+	NumInfo     int32
+	NumWarn     int32
+	NumError    int32
+	NumFatal    int32
+	beginLineNo int32
+	endLineNo   int32
+	predIds     []int32
+	succIds     []int32
 }
 
-
 func (s BasicBlock) String() string {
-	return fmt.Sprintf("%d %s %s %s %s %d", s.Complexity, s.PkgName, s.FuncName, s.Pos, s.EndPos, s.ID)
-}
-
-func buildBasicBlocks(f *ast.File, fset *token.FileSet, BasicBlocks []BasicBlock, counterMutex *sync.Mutex) []BasicBlock {
-	for _, decl := range f.Decls {
-		if fn, ok := decl.(*ast.FuncDecl); ok {
-			counterMutex.Lock()
-			BasicBlocks = append(BasicBlocks, BasicBlock{
-				PkgName:    f.Name.Name,
-				FuncName:   funcName(fn),
-				Complexity: complexity(fn),
-				Pos:        fset.Position(fn.Pos()),
-				EndPos:     fset.Position(fn.End()),
-				ID:         count,
-			})
-			count++
-			counterMutex.Unlock()
-		}
-	}
-	return BasicBlocks
+	return fmt.Sprintf("%d %s %s %s %s block#%d lines %d-%d preds=%v succs=%v logs=[trace:%d debug:%d info:%d warn:%d error:%d fatal:%d]",
+		s.Complexity, s.PkgName, s.FuncName, s.Pos, s.EndPos, s.ID, s.beginLineNo, s.endLineNo, s.predIds, s.succIds,
+		s.NumTrace, s.NumDebug, s.NumInfo, s.NumWarn, s.NumError, s.NumFatal)
 }
 
 // funcName returns the name representation of a function or method:
@@ -144,46 +102,14 @@ func recvString(recv ast.Expr) string {
 	return "BADRECV"
 }
 
-// complexity calculates the cyclomatic complexity of a function.
-func complexity(fn *ast.FuncDecl) int {
-	v := complexityVisitor{}
-	ast.Walk(&v, fn)
-	return v.Complexity
-}
-
-type complexityVisitor struct {
-	// Complexity is the cyclomatic complexity
-	Complexity int
-}
-
-// Visit implements the ast.Visitor interface.
-func (v *complexityVisitor) Visit(n ast.Node) ast.Visitor {
-	switch n := n.(type) {
-	case *ast.FuncDecl, *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt, *ast.CaseClause, *ast.CommClause:
-		v.Complexity++
-	case *ast.BinaryExpr:
-		if n.Op == token.LAND || n.Op == token.LOR {
-			v.Complexity++
-		}
-		// We might also need to check Go/Defer statements
-	case *ast.CallExpr:
-		fmt.Printf("%+v\n", n.Fun)
-	}
-	return v
-}
-
+// calculateShannonsEntropy returns the Shannon entropy, in bits, of a
+// probability distribution over log-trace equivalence classes.
 func calculateShannonsEntropy(probabilities []float64) float64 {
 	var sum float64
 	for _, probability := range probabilities {
-		sum += (probability * (math.Log2(float64(probability))))
+		if probability > 0 {
+			sum += probability * math.Log2(probability)
+		}
 	}
 	return -sum
 }
-
-func calculateProbablityOfSpecificLog(probabilities []float64) float64 {
-	var sum float64
-	for _, probability := range probabilities {
-		sum += (probability * (math.Log2(float64(probability))))
-	}
-	return -sum
-}
\ No newline at end of file
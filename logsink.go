@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"os"
+	"strings"
+)
+
+// Level is one of the severities BasicBlock's Num* counters track.
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelTrace:
+		return "trace"
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return "unknown"
+	}
+}
+
+// UnmarshalJSON accepts the level names used in a -logsinks config file,
+// e.g. "info" or "Info".
+func (l *Level) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	switch strings.ToLower(s) {
+	case "trace":
+		*l = LevelTrace
+	case "debug":
+		*l = LevelDebug
+	case "info":
+		*l = LevelInfo
+	case "warn", "warning":
+		*l = LevelWarn
+	case "error":
+		*l = LevelError
+	case "fatal", "panic":
+		*l = LevelFatal
+	default:
+		return fmt.Errorf("log20: unknown log level %q", s)
+	}
+	return nil
+}
+
+// LogSink identifies one logging call a LogSink registry should recognize:
+// either a package-level function (Recv == "") such as slog.Info, or a
+// method called on a logger value (Recv names the library's logger type,
+// for documentation -- the call site's receiver type isn't checked since
+// log20 doesn't run a type-checker, so Method alone drives the match for
+// receiver-style calls).
+type LogSink struct {
+	Pkg    string `json:"pkg"`
+	Recv   string `json:"recv"`
+	Method string `json:"method"`
+	Level  Level  `json:"level"`
+}
+
+// logRegistry is the set of LogSinks log20 recognizes. It starts out with
+// entries for the common Go logging libraries and can be extended with
+// project-specific loggers via -logsinks.
+var logRegistry = defaultLogSinks()
+
+func defaultLogSinks() []LogSink {
+	var sinks []LogSink
+	add := func(pkg, recv string, level Level, methods ...string) {
+		for _, m := range methods {
+			sinks = append(sinks, LogSink{Pkg: pkg, Recv: recv, Method: m, Level: level})
+		}
+	}
+
+	// standard library log
+	add("log", "", LevelFatal, "Fatal", "Fatalf", "Fatalln", "Panic", "Panicf", "Panicln")
+
+	// log/slog
+	add("log/slog", "", LevelDebug, "Debug", "Debugf", "DebugContext")
+	add("log/slog", "", LevelInfo, "Info", "Infof", "InfoContext")
+	add("log/slog", "", LevelWarn, "Warn", "Warnf", "WarnContext")
+	add("log/slog", "", LevelError, "Error", "Errorf", "ErrorContext")
+	add("log/slog", "Logger", LevelDebug, "Debug", "DebugContext")
+	add("log/slog", "Logger", LevelInfo, "Info", "InfoContext")
+	add("log/slog", "Logger", LevelWarn, "Warn", "WarnContext")
+	add("log/slog", "Logger", LevelError, "Error", "ErrorContext")
+
+	// github.com/sirupsen/logrus
+	add("github.com/sirupsen/logrus", "", LevelTrace, "Trace", "Tracef", "Traceln")
+	add("github.com/sirupsen/logrus", "", LevelDebug, "Debug", "Debugf", "Debugln")
+	add("github.com/sirupsen/logrus", "", LevelInfo, "Info", "Infof", "Infoln")
+	add("github.com/sirupsen/logrus", "", LevelWarn, "Warn", "Warnf", "Warnln", "Warning", "Warningf", "Warningln")
+	add("github.com/sirupsen/logrus", "", LevelError, "Error", "Errorf", "Errorln")
+	add("github.com/sirupsen/logrus", "", LevelFatal, "Fatal", "Fatalf", "Fatalln", "Panic", "Panicf", "Panicln")
+	add("github.com/sirupsen/logrus", "Entry", LevelTrace, "Trace", "Tracef", "Traceln")
+	add("github.com/sirupsen/logrus", "Entry", LevelDebug, "Debug", "Debugf", "Debugln")
+	add("github.com/sirupsen/logrus", "Entry", LevelInfo, "Info", "Infof", "Infoln")
+	add("github.com/sirupsen/logrus", "Entry", LevelWarn, "Warn", "Warnf", "Warnln", "Warning", "Warningf", "Warningln")
+	add("github.com/sirupsen/logrus", "Entry", LevelError, "Error", "Errorf", "Errorln")
+	add("github.com/sirupsen/logrus", "Entry", LevelFatal, "Fatal", "Fatalf", "Fatalln", "Panic", "Panicf", "Panicln")
+
+	// go.uber.org/zap (Logger and SugaredLogger)
+	add("go.uber.org/zap", "Logger", LevelDebug, "Debug")
+	add("go.uber.org/zap", "Logger", LevelInfo, "Info")
+	add("go.uber.org/zap", "Logger", LevelWarn, "Warn")
+	add("go.uber.org/zap", "Logger", LevelError, "Error")
+	add("go.uber.org/zap", "Logger", LevelFatal, "Fatal", "Panic")
+	add("go.uber.org/zap", "SugaredLogger", LevelDebug, "Debug", "Debugf", "Debugw")
+	add("go.uber.org/zap", "SugaredLogger", LevelInfo, "Info", "Infof", "Infow")
+	add("go.uber.org/zap", "SugaredLogger", LevelWarn, "Warn", "Warnf", "Warnw")
+	add("go.uber.org/zap", "SugaredLogger", LevelError, "Error", "Errorf", "Errorw")
+	add("go.uber.org/zap", "SugaredLogger", LevelFatal, "Fatal", "Fatalf", "Fatalw", "Panic", "Panicf", "Panicw")
+
+	// github.com/rs/zerolog: log.Info().Msg("..."), so the leveled call
+	// itself (which returns *zerolog.Event) is what we match on.
+	add("github.com/rs/zerolog", "Logger", LevelTrace, "Trace")
+	add("github.com/rs/zerolog", "Logger", LevelDebug, "Debug")
+	add("github.com/rs/zerolog", "Logger", LevelInfo, "Info")
+	add("github.com/rs/zerolog", "Logger", LevelWarn, "Warn")
+	add("github.com/rs/zerolog", "Logger", LevelError, "Error")
+	add("github.com/rs/zerolog", "Logger", LevelFatal, "Fatal", "Panic")
+
+	// k8s.io/klog
+	add("k8s.io/klog", "", LevelInfo, "Info", "Infof", "Infoln")
+	add("k8s.io/klog", "", LevelWarn, "Warning", "Warningf", "Warningln")
+	add("k8s.io/klog", "", LevelError, "Error", "Errorf", "Errorln")
+	add("k8s.io/klog", "", LevelFatal, "Fatal", "Fatalf", "Fatalln")
+	add("k8s.io/klog", "Verbose", LevelInfo, "Info", "Infof", "Infoln")
+
+	return sinks
+}
+
+// loadLogSinksConfig reads a JSON array of LogSink entries from path and
+// appends them to logRegistry, letting a project register its own loggers
+// (e.g. a thin wrapper around one of the libraries above).
+func loadLogSinksConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var extra []LogSink
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	logRegistry = append(logRegistry, extra...)
+	return nil
+}
+
+// fileImports maps the local identifier a file uses for each imported
+// package to that package's import path, e.g. "logrus" -> "github.com/sirupsen/logrus".
+func fileImports(f *ast.File) map[string]string {
+	imports := make(map[string]string, len(f.Imports))
+	for _, spec := range f.Imports {
+		path := strings.Trim(spec.Path.Value, `"`)
+		alias := path
+		if i := strings.LastIndex(alias, "/"); i >= 0 {
+			alias = alias[i+1:]
+		}
+		if spec.Name != nil {
+			alias = spec.Name.Name
+		}
+		imports[alias] = path
+	}
+	return imports
+}
+
+// loggerReceiverNames holds the conventional local-variable names a logger
+// is held in. Receiver-style sinks (sink.Recv != "") only fire for a call
+// whose receiver identifier is one of these, since log20 has no
+// type-checker to confirm the receiver's static type: without this
+// restriction, a single-word method name like "Error" or "Info" would
+// match any call with that name, including the ubiquitous err.Error().
+var loggerReceiverNames = map[string]bool{
+	"log":    true,
+	"logger": true,
+	"l":      true,
+	"lg":     true,
+	"logg":   true,
+}
+
+// logLevel reports the Level a call expression logs at, if it matches a
+// registered LogSink. slog's LogAttrs(ctx, level, msg, ...) is handled
+// specially since the level is passed as an argument rather than encoded
+// in the method name.
+func logLevel(call *ast.CallExpr, imports map[string]string) (Level, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return 0, false
+	}
+
+	if sel.Sel.Name == "LogAttrs" {
+		return slogAttrsLevel(call)
+	}
+
+	recvIdent, isIdent := sel.X.(*ast.Ident)
+	recvIsImport := isIdent && imports[recvIdent.Name] != ""
+
+	for _, sink := range logRegistry {
+		if sink.Method != sel.Sel.Name {
+			continue
+		}
+		if sink.Recv == "" {
+			if recvIsImport && imports[recvIdent.Name] == sink.Pkg {
+				return sink.Level, true
+			}
+			continue
+		}
+		// Receiver-style call (e.g. logger.Info(...)): without a
+		// type-checker we can't confirm logger's static type, so we
+		// only treat it as a hit when the receiver is a plain
+		// identifier with one of the conventional logger names --
+		// otherwise common unrelated methods like err.Error() would
+		// be counted as logging.
+		if !recvIsImport && isIdent && loggerReceiverNames[recvIdent.Name] {
+			return sink.Level, true
+		}
+	}
+	return 0, false
+}
+
+// slogAttrsLevel extracts the Level from a slog.LogAttrs(ctx, level, msg, attrs...) call.
+func slogAttrsLevel(call *ast.CallExpr) (Level, bool) {
+	if len(call.Args) < 2 {
+		return 0, false
+	}
+	sel, ok := call.Args[1].(*ast.SelectorExpr)
+	if !ok {
+		return 0, false
+	}
+	switch sel.Sel.Name {
+	case "LevelDebug":
+		return LevelDebug, true
+	case "LevelInfo":
+		return LevelInfo, true
+	case "LevelWarn":
+		return LevelWarn, true
+	case "LevelError":
+		return LevelError, true
+	}
+	return 0, false
+}
+
+// countLogCalls walks the statements owned by a basic block and tallies
+// the recognized log calls it makes, indexed by Level.
+func countLogCalls(owned []ast.Node, imports map[string]string) (counts [6]int32) {
+	for _, node := range owned {
+		ast.Inspect(node, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if level, ok := logLevel(call, imports); ok {
+				counts[level]++
+			}
+			return true
+		})
+	}
+	return counts
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"testing"
+)
+
+// parseCallExprs parses src as a file body and returns every *ast.CallExpr
+// it contains, along with the file's import map.
+func parseCallExprs(t *testing.T, src string) ([]*ast.CallExpr, map[string]string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "test.go", src, 0)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	var calls []*ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return calls, fileImports(f)
+}
+
+func TestLogLevelRecognizesLibraryCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Level
+	}{
+		{
+			name: "logrus package-level",
+			src: `package p
+import "github.com/sirupsen/logrus"
+func f() { logrus.Error("boom") }`,
+			want: LevelError,
+		},
+		{
+			name: "zap sugared logger by conventional name",
+			src: `package p
+func f(logger *SugaredLogger) { logger.Infow("started", "port", 8080) }`,
+			want: LevelInfo,
+		},
+		{
+			name: "slog package-level",
+			src: `package p
+import "log/slog"
+func f() { slog.Warn("careful") }`,
+			want: LevelWarn,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, imports := parseCallExprs(t, tt.src)
+			var found bool
+			for _, call := range calls {
+				if level, ok := logLevel(call, imports); ok {
+					if level != tt.want {
+						t.Errorf("logLevel() = %v, want %v", level, tt.want)
+					}
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("logLevel() found no match in %q", tt.src)
+			}
+		})
+	}
+}
+
+func TestLogLevelIgnoresUnrelatedErrorCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "err.Error()",
+			src: `package p
+func f(err error) string { return err.Error() }`,
+		},
+		{
+			name: "struct field named Error",
+			src: `package p
+func f(r Result) string { return r.Error() }`,
+		},
+		{
+			name: "Info method on an unrelated type",
+			src: `package p
+func f(d Disk) int64 { return d.Info().Size }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, imports := parseCallExprs(t, tt.src)
+			for _, call := range calls {
+				if level, ok := logLevel(call, imports); ok {
+					t.Errorf("logLevel() = %v, true; want no match", level)
+				}
+			}
+		})
+	}
+}
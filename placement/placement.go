@@ -0,0 +1,344 @@
+// Package placement implements Log20's entropy-based log placement
+// recommender: given a function's control-flow graph and the blocks that
+// already carry a log statement, it suggests where to add more so that
+// the resulting log trace disambiguates execution paths as cheaply as
+// possible.
+package placement
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Level mirrors the severities a log statement can be recommended at.
+type Level int
+
+const (
+	LevelTrace Level = iota
+	LevelDebug
+	LevelInfo
+	LevelWarn
+	LevelError
+	LevelFatal
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelTrace:
+		return "trace"
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	case LevelFatal:
+		return "fatal"
+	default:
+		return "info"
+	}
+}
+
+// Block is one basic block of a function's control-flow graph, as seen by
+// the placement recommender. FuncID must be the same value for every block
+// of one function and unique across functions (e.g. "file.go:123").
+type Block struct {
+	ID        int32
+	FuncID    string
+	File      string
+	BeginLine int32
+	EndLine   int32
+	Preds     []int32
+	Succs     []int32
+	// LogCount is the number of log statements already attributed to
+	// this block (e.g. the sum of a BasicBlock's Num* counters).
+	LogCount int32
+}
+
+func (b Block) alreadyLogged() bool { return b.LogCount > 0 }
+
+// Recommendation suggests inserting a log statement at File:Line.
+type Recommendation struct {
+	File  string
+	Line  int32
+	Level Level
+	// Gain is the entropy reduction, in bits, this recommendation buys
+	// over the recommendations already emitted for its function.
+	Gain float64
+}
+
+func (r Recommendation) String() string {
+	return fmt.Sprintf("%s:%d: insert %s log", r.File, r.Line, r.Level)
+}
+
+// Config tunes the recommender.
+type Config struct {
+	// LoopLimit (k) bounds how many times a single block may repeat
+	// along one enumerated path, so loops are unrolled a bounded number
+	// of times instead of explored exhaustively.
+	LoopLimit int
+	// BudgetOpsPerSec caps the total estimated runtime overhead the
+	// recommended log calls may add, in calls/sec. Zero means unlimited
+	// (recommend until every path is disambiguated).
+	BudgetOpsPerSec float64
+	// LogCallCost is the estimated cost of one log call, in the same
+	// units as BudgetOpsPerSec's "ops" -- e.g. 1 for "one op per call",
+	// or >1 if a log call is modeled as costing more than a typical op.
+	LogCallCost float64
+	// DefaultCallRate is the calls/sec assumed for a block when no
+	// --profile data covers it.
+	DefaultCallRate float64
+	// Level is the severity recommended for every inserted log. The
+	// recommender only decides *where* to log, not what severity fits,
+	// so it defers to this operator-supplied default.
+	Level Level
+}
+
+// DefaultConfig returns the recommender's defaults.
+func DefaultConfig() Config {
+	return Config{
+		LoopLimit:       2,
+		BudgetOpsPerSec: 0,
+		LogCallCost:     1,
+		DefaultCallRate: 1,
+		Level:           LevelInfo,
+	}
+}
+
+// Recommend analyzes every function present in blocks and returns the log
+// placements it suggests, most valuable first within each function.
+func Recommend(blocks []Block, profile map[int32]float64, cfg Config) []Recommendation {
+	var recs []Recommendation
+	for _, fn := range groupByFunc(blocks) {
+		recs = append(recs, recommendFunc(fn, profile, cfg)...)
+	}
+	return recs
+}
+
+type function struct {
+	id      string
+	blocks  map[int32]Block
+	order   []int32 // block IDs in creation order; order[0] is the entry
+	entryID int32
+}
+
+func groupByFunc(blocks []Block) []function {
+	index := map[string]*function{}
+	var order []string
+	for _, blk := range blocks {
+		fn, ok := index[blk.FuncID]
+		if !ok {
+			fn = &function{id: blk.FuncID, blocks: map[int32]Block{}}
+			index[blk.FuncID] = fn
+			order = append(order, blk.FuncID)
+		}
+		fn.blocks[blk.ID] = blk
+		fn.order = append(fn.order, blk.ID)
+	}
+	fns := make([]function, 0, len(order))
+	for _, id := range order {
+		fn := index[id]
+		if len(fn.order) > 0 {
+			fn.entryID = fn.order[0]
+		}
+		fns = append(fns, *fn)
+	}
+	return fns
+}
+
+// path is one walk from a function's entry to one of its exit blocks (or
+// to a block whose every successor has hit the loop-unrolling limit).
+type path []int32
+
+func enumeratePaths(fn function, loopLimit int) []path {
+	if loopLimit < 1 {
+		loopLimit = 1
+	}
+	var paths []path
+	var walk func(cur int32, visited map[int32]int, soFar path)
+	walk = func(cur int32, visited map[int32]int, soFar path) {
+		soFar = append(soFar, cur)
+		visited[cur]++
+
+		blk := fn.blocks[cur]
+		var next []int32
+		for _, succ := range blk.Succs {
+			if visited[succ] < loopLimit {
+				next = append(next, succ)
+			}
+		}
+		if len(next) == 0 {
+			complete := make(path, len(soFar))
+			copy(complete, soFar)
+			paths = append(paths, complete)
+			visited[cur]--
+			return
+		}
+		for _, succ := range next {
+			walk(succ, visited, soFar)
+		}
+		visited[cur]--
+	}
+	walk(fn.entryID, map[int32]int{}, nil)
+	return paths
+}
+
+// weight estimates how likely a path is to occur, from --profile hit
+// counts when available, falling back to a uniform weight.
+func weight(p path, fn function, profile map[int32]float64) float64 {
+	if profile == nil {
+		return 1
+	}
+	var sum, n float64
+	for _, id := range p {
+		if hits, ok := profile[id]; ok {
+			sum += hits
+			n++
+		}
+	}
+	if n == 0 {
+		return 1
+	}
+	return sum / n
+}
+
+// traceKey is the ordered sequence of observed block IDs a path produces,
+// used as the equivalence-class key for entropy().
+func traceKey(p path, observed map[int32]bool) string {
+	key := make([]byte, 0, len(p)*5)
+	for _, id := range p {
+		if observed[id] {
+			key = append(key, []byte(fmt.Sprintf("/%d", id))...)
+		}
+	}
+	return string(key)
+}
+
+// entropy estimates the uncertainty that remains about which path
+// executed once the log trace from the blocks in observed is known: the
+// conditional entropy H(path|trace), in bits. It is 0 once every
+// equivalence class contains exactly one path (the trace pins down the
+// path exactly) and is largest when observed doesn't distinguish any
+// paths at all -- which is why placement recommends observations that
+// drive this value down, stopping once it reaches 0.
+func entropy(paths []path, fn function, profile map[int32]float64, observed map[int32]bool) float64 {
+	type class struct {
+		weights []float64
+		total   float64
+	}
+	classes := map[string]*class{}
+	var total float64
+	for _, p := range paths {
+		w := weight(p, fn, profile)
+		key := traceKey(p, observed)
+		c, ok := classes[key]
+		if !ok {
+			c = &class{}
+			classes[key] = c
+		}
+		c.weights = append(c.weights, w)
+		c.total += w
+		total += w
+	}
+	if total == 0 {
+		return 0
+	}
+	var h float64
+	for _, c := range classes {
+		pClass := c.total / total
+		var within float64
+		for _, w := range c.weights {
+			p := w / c.total
+			if p > 0 {
+				within -= p * math.Log2(p)
+			}
+		}
+		h += pClass * within
+	}
+	return h
+}
+
+func recommendFunc(fn function, profile map[int32]float64, cfg Config) []Recommendation {
+	paths := enumeratePaths(fn, cfg.LoopLimit)
+	if len(paths) == 0 {
+		return nil
+	}
+
+	observed := map[int32]bool{}
+	for id, blk := range fn.blocks {
+		if blk.alreadyLogged() {
+			observed[id] = true
+		}
+	}
+
+	candidates := make([]int32, 0, len(fn.blocks))
+	for id, blk := range fn.blocks {
+		if !blk.alreadyLogged() {
+			candidates = append(candidates, id)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i] < candidates[j] })
+
+	budget := cfg.BudgetOpsPerSec
+	unlimited := budget <= 0
+	current := entropy(paths, fn, profile, observed)
+
+	var recs []Recommendation
+	for current > 1e-9 && len(candidates) > 0 {
+		bestIdx := -1
+		var bestGain, bestCost, bestScore float64
+		for i, id := range candidates {
+			observed[id] = true
+			h := entropy(paths, fn, profile, observed)
+			observed[id] = false
+
+			gain := current - h
+			if gain <= 0 {
+				continue
+			}
+			cost := callRate(id, profile, cfg) * cfg.LogCallCost
+			score := gain
+			if cost > 0 {
+				score = gain / cost
+			}
+			if bestIdx == -1 || score > bestScore {
+				bestIdx, bestGain, bestCost, bestScore = i, gain, cost, score
+			}
+		}
+		if bestIdx == -1 {
+			break
+		}
+		if !unlimited && bestCost > budget {
+			break
+		}
+
+		id := candidates[bestIdx]
+		observed[id] = true
+		current -= bestGain
+		if !unlimited {
+			budget -= bestCost
+		}
+
+		blk := fn.blocks[id]
+		recs = append(recs, Recommendation{
+			File:  blk.File,
+			Line:  blk.BeginLine,
+			Level: cfg.Level,
+			Gain:  bestGain,
+		})
+		candidates = append(candidates[:bestIdx], candidates[bestIdx+1:]...)
+	}
+	return recs
+}
+
+func callRate(id int32, profile map[int32]float64, cfg Config) float64 {
+	if profile != nil {
+		if rate, ok := profile[id]; ok {
+			return rate
+		}
+	}
+	return cfg.DefaultCallRate
+}
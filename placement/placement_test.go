@@ -0,0 +1,72 @@
+package placement
+
+// These tests exercise the shape of bug this package inherited from the
+// log-call detector (log20#chunk0-2): a block whose only "log" call was
+// really something like err.Error() would be wrongly treated as already
+// logged (LogCount > 0) and excluded from recommendation, even though it's
+// exactly the error-path block the recommender should flag. The detector
+// no longer miscounts those calls, so here we just confirm this package's
+// own logic -- alreadyLogged and the recommend loop -- does the right
+// thing once LogCount reflects real log calls.
+
+import "testing"
+
+func TestBlockAlreadyLogged(t *testing.T) {
+	tests := []struct {
+		name     string
+		logCount int32
+		want     bool
+	}{
+		{name: "no log calls", logCount: 0, want: false},
+		{name: "one log call", logCount: 1, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Block{LogCount: tt.logCount}
+			if got := b.alreadyLogged(); got != tt.want {
+				t.Errorf("alreadyLogged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// threeGuardClauseBlocks mirrors the CFG log20 builds for:
+//
+//	func f(a, b, c bool) int {
+//		if a { return 1 }
+//		if b { return 2 }
+//		if c { return 3 }
+//		return 4
+//	}
+//
+// block 1 is the entry; 2, 3, 4 are the three early-return blocks; 5 is
+// the final return. None carry a log call.
+func threeGuardClauseBlocks() []Block {
+	return []Block{
+		{ID: 1, FuncID: "f", Succs: []int32{2, 3}},
+		{ID: 2, FuncID: "f", Preds: []int32{1}, Succs: nil},
+		{ID: 3, FuncID: "f", Preds: []int32{1}, Succs: []int32{4, 5}},
+		{ID: 4, FuncID: "f", Preds: []int32{3}, Succs: nil},
+		{ID: 5, FuncID: "f", Preds: []int32{3}, Succs: nil},
+	}
+}
+
+func TestRecommendSkipsBlocksWithRealLogCalls(t *testing.T) {
+	blocks := threeGuardClauseBlocks()
+	cfg := DefaultConfig()
+
+	withoutLogs := Recommend(blocks, nil, cfg)
+	if len(withoutLogs) == 0 {
+		t.Fatalf("Recommend() with no logged blocks returned no recommendations")
+	}
+
+	// Give block 2 a real log call: it must no longer be a candidate.
+	logged := make([]Block, len(blocks))
+	copy(logged, blocks)
+	logged[1].LogCount = 1
+
+	withLogs := Recommend(logged, nil, cfg)
+	if len(withLogs) >= len(withoutLogs) {
+		t.Errorf("Recommend() with block 2 already logged returned %d recommendations, want fewer than %d", len(withLogs), len(withoutLogs))
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/LogTwenty/Log20-Go/placement"
+)
+
+var (
+	recommendFlag = flag.Bool("recommend", false, "recommend log placements instead of printing basic blocks")
+	profileFlag   = flag.String("profile", "", "path to a JSON file mapping basic-block ID to an observed calls/sec (from go tool trace or coverage)")
+	budgetFlag    = flag.Float64("budget-ops-per-sec", 0, "stop recommending once the added log calls would cost more than this many ops/sec (0 = unlimited)")
+	loopLimitFlag = flag.Int("loop-limit", placement.DefaultConfig().LoopLimit, "max times a block may repeat along one enumerated path (k)")
+)
+
+// recommendPlacements converts BasicBlocks to the placement package's view
+// of the CFG and prints the log placements it recommends.
+func recommendPlacements(w io.Writer, blocks []BasicBlock) error {
+	profile, err := loadProfile(*profileFlag)
+	if err != nil {
+		return err
+	}
+
+	cfg := placement.DefaultConfig()
+	cfg.BudgetOpsPerSec = *budgetFlag
+	cfg.LoopLimit = *loopLimitFlag
+
+	recs := placement.Recommend(toPlacementBlocks(blocks), profile, cfg)
+	for _, rec := range recs {
+		fmt.Fprintln(w, rec)
+	}
+	return nil
+}
+
+func toPlacementBlocks(blocks []BasicBlock) []placement.Block {
+	out := make([]placement.Block, len(blocks))
+	for i, b := range blocks {
+		out[i] = placement.Block{
+			ID:        int32(b.ID),
+			FuncID:    fmt.Sprintf("%s:%d", b.Pos.Filename, b.Pos.Offset),
+			File:      b.Pos.Filename,
+			BeginLine: b.beginLineNo,
+			EndLine:   b.endLineNo,
+			Preds:     b.predIds,
+			Succs:     b.succIds,
+			LogCount:  b.NumTrace + b.NumDebug + b.NumInfo + b.NumWarn + b.NumError + b.NumFatal,
+		}
+	}
+	return out
+}
+
+// loadProfile reads a JSON file of {"<blockID>": <calls/sec>} into a
+// map[int32]float64, or returns nil if path is empty.
+func loadProfile(path string) (map[int32]float64, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]float64
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	profile := make(map[int32]float64, len(raw))
+	for k, v := range raw {
+		var id int32
+		if _, err := fmt.Sscanf(k, "%d", &id); err != nil {
+			return nil, fmt.Errorf("log20: invalid block ID %q in profile: %w", k, err)
+		}
+		profile[id] = v
+	}
+	return profile, nil
+}